@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenTTL is how long a token issued by /auth/token remains valid.
+const tokenTTL = time.Hour
+
+// scopeRank orders the scopes a token can carry so a higher scope
+// satisfies any requirement a lower one would. "admin" covers DELETEs;
+// "write" covers the POST endpoints; "read" is only checked here if a
+// future endpoint needs it, since /search is currently public.
+var scopeRank = map[string]int{"read": 1, "write": 2, "admin": 3}
+
+func scopeAllows(have, need string) bool {
+	return scopeRank[have] >= scopeRank[need]
+}
+
+// scopeClaims is the JWT claim set this service issues and validates: the
+// registered claims plus a single scope string.
+type scopeClaims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// jwtAuth signs and validates the service's JWTs. It supports HS256 when
+// only JWT_PRIVATE_KEY is set (treated as a shared secret) and RS256 when
+// both JWT_PRIVATE_KEY and JWT_PUBLIC_KEY hold PEM-encoded RSA keys.
+type jwtAuth struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func newJWTAuth() (*jwtAuth, error) {
+	priv := os.Getenv("JWT_PRIVATE_KEY")
+	if priv == "" {
+		return nil, fmt.Errorf("auth: JWT_PRIVATE_KEY is required")
+	}
+
+	if strings.Contains(priv, "BEGIN") {
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(priv))
+		if err != nil {
+			return nil, fmt.Errorf("auth: parse JWT_PRIVATE_KEY: %w", err)
+		}
+		pub := os.Getenv("JWT_PUBLIC_KEY")
+		if pub == "" {
+			return nil, fmt.Errorf("auth: JWT_PUBLIC_KEY is required alongside an RSA JWT_PRIVATE_KEY")
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM([]byte(pub))
+		if err != nil {
+			return nil, fmt.Errorf("auth: parse JWT_PUBLIC_KEY: %w", err)
+		}
+		return &jwtAuth{method: jwt.SigningMethodRS256, signKey: privateKey, verifyKey: publicKey}, nil
+	}
+
+	secret := []byte(priv)
+	return &jwtAuth{method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+func (a *jwtAuth) issueToken(subject, scope string) (string, error) {
+	now := time.Now()
+	claims := scopeClaims{
+		Scope: scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+		},
+	}
+	return jwt.NewWithClaims(a.method, claims).SignedString(a.signKey)
+}
+
+func (a *jwtAuth) parse(r *http.Request) (*scopeClaims, error) {
+	tokenStr, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || tokenStr == "" {
+		return nil, fmt.Errorf("missing bearer token")
+	}
+
+	claims := &scopeClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != a.method {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return a.verifyKey, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return claims, nil
+}
+
+// requireScope is chi middleware that rejects requests whose bearer
+// token is missing, invalid, or carries a scope below need.
+func (a *jwtAuth) requireScope(need string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := a.parse(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if !scopeAllows(claims.Scope, need) {
+				http.Error(w, "insufficient scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type tokenRequest struct {
+	Subject string `json:"subject"`
+	Scope   string `json:"scope"`
+}
+
+// issuerSecret is a pre-shared credential (distinct from the JWT signing
+// key) that a caller must present to mint a token above "read" scope. It
+// is how a deploy's own provisioning tooling authenticates itself to this
+// endpoint; it is never embedded in an issued token.
+func issuerSecret() string {
+	return os.Getenv("TOKEN_ISSUER_SECRET")
+}
+
+// issueTokenHandler mints a token for the requested scope. Anyone may
+// request a "read" token, since /search is already public; "write" and
+// "admin" require the caller to present TOKEN_ISSUER_SECRET via the
+// X-Issuer-Secret header, so self-service scope escalation isn't possible
+// without a credential that's authenticated out of band.
+func issueTokenHandler(auth *jwtAuth) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req tokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := scopeRank[req.Scope]; !ok {
+			http.Error(w, fmt.Sprintf("invalid scope %q", req.Scope), http.StatusBadRequest)
+			return
+		}
+
+		if req.Scope != "read" {
+			secret := issuerSecret()
+			if secret == "" || !hmac.Equal([]byte(r.Header.Get("X-Issuer-Secret")), []byte(secret)) {
+				http.Error(w, "issuing a write or admin token requires a valid X-Issuer-Secret", http.StatusForbidden)
+				return
+			}
+		}
+
+		token, err := auth.issueToken(req.Subject, req.Scope)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"token": token})
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer"
+)
+
+// bulkStagingTable receives the raw COPY payload before a single
+// INSERT ... SELECT ... RETURNING moves it into products, since COPY
+// itself cannot return the SERIAL ids it generated.
+const bulkStagingTable = "products_bulk_staging"
+
+// bulkStatus is one NDJSON status line reported per input record.
+type bulkStatus struct {
+	Line  int    `json:"line"`
+	ID    *int   `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// addBulkProductHandler ingests a batch of products in a single COPY
+// round trip and a single Bleve batch, rather than the O(N) round trips
+// addProductHandler would impose one record at a time. It accepts either
+// a JSON array body or a newline-delimited JSON stream, and reports a
+// status line per record as NDJSON so a client can process results
+// without waiting for the whole batch to be re-encoded.
+func addBulkProductHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		products, err := decodeBulkProducts(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(products) == 0 {
+			http.Error(w, "No products in request body", http.StatusBadRequest)
+			return
+		}
+
+		ids, insertErr := bulkInsertProducts(r.Context(), products)
+
+		var indexErr error
+		if insertErr == nil {
+			docs := make([]indexer.Document, len(products))
+			for i, p := range products {
+				p.ID = ids[i]
+				docs[i] = indexer.Document{ID: strconv.Itoa(p.ID), Name: p.Name, Category: p.Category}
+
+				cacheLock.Lock()
+				cache.Add(p.ID, p)
+				cacheLock.Unlock()
+			}
+			indexErr = idx.Batch(r.Context(), docs)
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		flusher, _ := w.(http.Flusher)
+
+		for i := range products {
+			status := bulkStatus{Line: i + 1}
+			switch {
+			case insertErr != nil:
+				status.Error = insertErr.Error()
+			default:
+				id := ids[i]
+				status.ID = &id
+				if indexErr != nil {
+					status.Error = indexErr.Error()
+				}
+			}
+			enc.Encode(status)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// decodeBulkProducts accepts either a JSON array of products or a
+// newline-delimited stream of product objects, detected by peeking the
+// first non-whitespace byte of the body.
+func decodeBulkProducts(body io.Reader) ([]Product, error) {
+	br := bufio.NewReader(body)
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != ' ' && b[0] != '\n' && b[0] != '\r' && b[0] != '\t' {
+			break
+		}
+		br.Discard(1)
+	}
+
+	first, _ := br.Peek(1)
+	if len(first) > 0 && first[0] == '[' {
+		var products []Product
+		if err := json.NewDecoder(br).Decode(&products); err != nil {
+			return nil, fmt.Errorf("decode product array: %w", err)
+		}
+		return products, nil
+	}
+
+	var products []Product
+	scanner := bufio.NewScanner(br)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var p Product
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			return nil, fmt.Errorf("decode line %d: %w", len(products)+1, err)
+		}
+		products = append(products, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// bulkInsertProducts loads products into Postgres with a single COPY
+// into a staging table, then moves them into products with one
+// INSERT ... SELECT ... RETURNING to recover the generated ids in input
+// order, instead of one INSERT ... RETURNING per record.
+func bulkInsertProducts(ctx context.Context, products []Product) ([]int, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `CREATE TEMPORARY TABLE `+bulkStagingTable+` (
+		seq      INT,
+		name     TEXT,
+		category TEXT
+	) ON COMMIT DROP`); err != nil {
+		return nil, fmt.Errorf("create staging table: %w", err)
+	}
+
+	rows := make([][]interface{}, len(products))
+	for i, p := range products {
+		rows[i] = []interface{}{i, p.Name, p.Category}
+	}
+
+	if err := conn.Raw(func(driverConn interface{}) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		_, err := pgxConn.CopyFrom(ctx,
+			pgx.Identifier{bulkStagingTable},
+			[]string{"seq", "name", "category"},
+			pgx.CopyFromRows(rows),
+		)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("copy into staging table: %w", err)
+	}
+
+	idRows, err := tx.QueryContext(ctx, `
+		INSERT INTO products (name, category)
+		SELECT name, category FROM `+bulkStagingTable+` ORDER BY seq
+		RETURNING id`)
+	if err != nil {
+		return nil, fmt.Errorf("insert from staging table: %w", err)
+	}
+	defer idRows.Close()
+
+	ids := make([]int, 0, len(products))
+	for idRows.Next() {
+		var id int
+		if err := idRows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := idRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
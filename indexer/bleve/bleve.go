@@ -0,0 +1,358 @@
+// Package bleve adapts the embedded Bleve search library to the
+// indexer.Indexer interface, keeping its index on disk and in sync with
+// Postgres via logical replication (see replication.go).
+package bleve
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	blevelib "github.com/blevesearch/bleve/v2"
+	blevesearch "github.com/blevesearch/bleve/v2/search"
+	blevequery "github.com/blevesearch/bleve/v2/search/query"
+	"github.com/jackc/pglogrepl"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/internal"
+)
+
+// defaultHitsPerPage matches the Algolia-style pagination defaults this
+// response format is modeled on.
+const defaultHitsPerPage = 20
+
+// IndexVersion is bumped whenever the index mapping changes in a way that
+// requires a full rebuild. Engine.Init compares it against the version
+// stamped on the index the last time it was built and rebuilds from db
+// when they differ. Bumped to 2 when Category became a mapped (and thus
+// facetable) field.
+const IndexVersion = 2
+
+const versionInternalKey = "_schema_version"
+
+const defaultIndexPath = "./data/bleve_index"
+
+// Engine is a Bleve-backed implementation of internal.Indexer. Its index
+// lives on disk at path and is kept current by a background logical
+// replication syncer (see replication.go) rather than being rebuilt from
+// scratch on every boot.
+type Engine struct {
+	db          *sql.DB
+	replConnStr string
+	path        string
+
+	index       blevelib.Index
+	lastApplied atomic.Uint64 // pglogrepl.LSN of the last WAL record applied
+}
+
+// New returns a Bleve engine whose index is persisted under INDEX_PATH
+// (defaulting to defaultIndexPath) and kept in sync from the products
+// table over the replConnStr connection, a Postgres connection string
+// with replication=database set.
+func New(db *sql.DB, replConnStr string) *Engine {
+	path := os.Getenv("INDEX_PATH")
+	if path == "" {
+		path = defaultIndexPath
+	}
+	return &Engine{db: db, replConnStr: replConnStr, path: path}
+}
+
+func (e *Engine) Init(ctx context.Context) error {
+	index, created, err := e.openOrCreate()
+	if err != nil {
+		return fmt.Errorf("bleve: open index: %w", err)
+	}
+	e.index = index
+
+	if err := e.ensureStateTable(ctx); err != nil {
+		return fmt.Errorf("bleve: ensure state table: %w", err)
+	}
+
+	stale := created
+	if !created {
+		stale, err = e.isStale()
+		if err != nil {
+			return fmt.Errorf("bleve: check schema version: %w", err)
+		}
+		if stale {
+			if err := e.index.Close(); err != nil {
+				return fmt.Errorf("bleve: close stale index: %w", err)
+			}
+			if err := os.RemoveAll(e.path); err != nil {
+				return fmt.Errorf("bleve: remove stale index: %w", err)
+			}
+			if e.index, err = e.create(); err != nil {
+				return fmt.Errorf("bleve: recreate index: %w", err)
+			}
+		}
+	}
+
+	var startLSN pglogrepl.LSN
+	if stale {
+		if err := e.ensurePublication(ctx); err != nil {
+			return fmt.Errorf("bleve: ensure publication: %w", err)
+		}
+
+		var snapshotName string
+		startLSN, snapshotName, err = e.createReplicationSlot(ctx)
+		if err != nil {
+			return fmt.Errorf("bleve: create replication slot: %w", err)
+		}
+
+		// Backfill against the snapshot the slot exported, so the rows we
+		// read are exactly the ones as-of startLSN: nothing committed
+		// after slot creation is missed, and nothing the syncer will
+		// also replay is double-counted.
+		if err := e.backfill(ctx, snapshotName); err != nil {
+			return fmt.Errorf("bleve: backfill: %w", err)
+		}
+		if err := e.index.SetInternal([]byte(versionInternalKey), []byte(strconv.Itoa(IndexVersion))); err != nil {
+			return fmt.Errorf("bleve: stamp schema version: %w", err)
+		}
+		if err := e.storeCheckpoint(ctx, startLSN); err != nil {
+			return fmt.Errorf("bleve: store initial checkpoint: %w", err)
+		}
+	} else {
+		startLSN, err = e.loadCheckpoint(ctx)
+		if err != nil {
+			return fmt.Errorf("bleve: load checkpoint: %w", err)
+		}
+	}
+
+	e.lastApplied.Store(uint64(startLSN))
+	go e.runSync(startLSN)
+	return nil
+}
+
+func (e *Engine) Ping(ctx context.Context) error {
+	if e.index == nil {
+		return fmt.Errorf("bleve: index not initialized")
+	}
+	return e.db.PingContext(ctx)
+}
+
+func (e *Engine) Close() error {
+	if e.index == nil {
+		return nil
+	}
+	return e.index.Close()
+}
+
+func (e *Engine) Index(ctx context.Context, doc internal.Document) error {
+	return e.index.Index(doc.ID, toBlevePayload(doc))
+}
+
+func (e *Engine) Delete(ctx context.Context, id string) error {
+	return e.index.Delete(id)
+}
+
+func (e *Engine) Batch(ctx context.Context, docs []internal.Document) error {
+	batch := e.index.NewBatch()
+	for _, doc := range docs {
+		if err := batch.Index(doc.ID, toBlevePayload(doc)); err != nil {
+			return err
+		}
+	}
+	return e.index.Batch(batch)
+}
+
+func toBlevePayload(doc internal.Document) map[string]interface{} {
+	return map[string]interface{}{
+		"ID":       doc.ID,
+		"Name":     doc.Name,
+		"Category": doc.Category,
+	}
+}
+
+func (e *Engine) Search(ctx context.Context, params internal.SearchParams) (*internal.SearchResult, error) {
+	page := params.Page
+	if page < 1 {
+		page = 1
+	}
+	hitsPerPage := params.HitsPerPage
+	if hitsPerPage <= 0 {
+		hitsPerPage = defaultHitsPerPage
+	}
+
+	searchRequest := blevelib.NewSearchRequestOptions(buildQuery(params), hitsPerPage, (page-1)*hitsPerPage, false)
+	searchRequest.Highlight = blevelib.NewHighlight()
+	searchRequest.Fields = []string{"Name", "Category"}
+	for _, field := range params.FacetFields {
+		searchRequest.AddFacet(field, blevelib.NewFacetRequest(field, 20))
+	}
+
+	result, err := e.index.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]internal.SearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		hits = append(hits, internal.SearchHit{
+			ID:              hit.ID,
+			Score:           hit.Score,
+			HighlightResult: highlightResult(hit),
+		})
+	}
+
+	nbPages := int((result.Total + uint64(hitsPerPage) - 1) / uint64(hitsPerPage))
+	return &internal.SearchResult{
+		Hits:        hits,
+		Page:        page,
+		HitsPerPage: hitsPerPage,
+		NbHits:      result.Total,
+		NbPages:     nbPages,
+		Facets:      decodeFacets(result.Facets),
+	}, nil
+}
+
+// buildQuery turns free text plus any ?filter= constraints into a single
+// bleve query: a bare MatchQuery when there are no filters, otherwise a
+// ConjunctionQuery ANDing the match against a TermQuery per filter value.
+func buildQuery(params internal.SearchParams) blevequery.Query {
+	match := blevelib.NewMatchQuery(params.Query)
+	if len(params.Filters) == 0 {
+		return match
+	}
+
+	conjuncts := []blevequery.Query{match}
+	for field, values := range params.Filters {
+		for _, value := range values {
+			term := blevelib.NewTermQuery(value)
+			term.SetField(field)
+			conjuncts = append(conjuncts, term)
+		}
+	}
+	return blevelib.NewConjunctionQuery(conjuncts...)
+}
+
+// highlightResult translates bleve's per-field fragments and term
+// locations into the Algolia-style highlightResult map, classifying each
+// field's matchLevel by how much of its token set was matched.
+func highlightResult(hit *blevesearch.DocumentMatch) map[string]internal.Highlight {
+	result := make(map[string]internal.Highlight, len(hit.Fragments))
+	for field, fragments := range hit.Fragments {
+		var value string
+		if len(fragments) > 0 {
+			value = fragments[0]
+		}
+
+		words := matchedWords(hit, field)
+		level := internal.MatchNone
+		if len(words) > 0 {
+			level = internal.MatchPartial
+			if original, ok := storedFieldValue(hit, field); ok && len(words) >= len(strings.Fields(original)) {
+				level = internal.MatchFull
+			}
+		}
+
+		result[field] = internal.Highlight{Value: value, MatchLevel: level, MatchedWords: words}
+	}
+	return result
+}
+
+func matchedWords(hit *blevesearch.DocumentMatch, field string) []string {
+	terms, ok := hit.Locations[field]
+	if !ok {
+		return nil
+	}
+	words := make([]string, 0, len(terms))
+	for term := range terms {
+		words = append(words, term)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func storedFieldValue(hit *blevesearch.DocumentMatch, field string) (string, bool) {
+	raw, ok := hit.Fields[field]
+	if !ok {
+		return "", false
+	}
+	value, ok := raw.(string)
+	return value, ok
+}
+
+// decodeFacets converts bleve's facet results into the backend-agnostic
+// FacetCount form.
+func decodeFacets(facets blevesearch.FacetResults) map[string][]internal.FacetCount {
+	if len(facets) == 0 {
+		return nil
+	}
+	out := make(map[string][]internal.FacetCount, len(facets))
+	for field, facet := range facets {
+		counts := make([]internal.FacetCount, 0, len(facet.Terms.Terms()))
+		for _, term := range facet.Terms.Terms() {
+			counts = append(counts, internal.FacetCount{Value: term.Term, Count: uint64(term.Count)})
+		}
+		out[field] = counts
+	}
+	return out
+}
+
+// openOrCreate opens the on-disk index at e.path, creating it (and
+// reporting created=true) if nothing is there yet.
+func (e *Engine) openOrCreate() (idx blevelib.Index, created bool, err error) {
+	idx, err = blevelib.Open(e.path)
+	if err == nil {
+		return idx, false, nil
+	}
+	if err != blevelib.ErrorIndexPathDoesNotExist {
+		return nil, false, err
+	}
+	idx, err = e.create()
+	return idx, true, err
+}
+
+func (e *Engine) create() (blevelib.Index, error) {
+	mapping := blevelib.NewIndexMapping()
+	docMapping := blevelib.NewDocumentMapping()
+
+	nameField := blevelib.NewTextFieldMapping()
+	nameField.Analyzer = "en"
+	docMapping.AddFieldMappingsAt("Name", nameField)
+
+	categoryField := blevelib.NewTextFieldMapping()
+	categoryField.Analyzer = "keyword"
+	docMapping.AddFieldMappingsAt("Category", categoryField)
+
+	mapping.AddDocumentMapping("product", docMapping)
+	// Payloads carry no _type field, so route them to "product" by default.
+	mapping.DefaultType = "product"
+	mapping.DefaultAnalyzer = "en"
+
+	return blevelib.New(e.path, mapping)
+}
+
+// isStale reports whether the on-disk index was built under an older
+// IndexVersion and needs to be rebuilt from scratch.
+func (e *Engine) isStale() (bool, error) {
+	raw, err := e.index.GetInternal([]byte(versionInternalKey))
+	if err != nil {
+		return false, err
+	}
+	if raw == nil {
+		return true, nil
+	}
+	version, err := strconv.Atoi(string(raw))
+	if err != nil {
+		return true, nil
+	}
+	return version != IndexVersion, nil
+}
+
+// backfill rebuilds the index from the products table via
+// internal.BackfillFromPostgres. snapshotName, exported by
+// createReplicationSlot, pins the backfill's view of the table to
+// exactly the replication slot's consistent point, so the backfill and
+// the syncer that resumes from that LSN can't see the same write twice
+// or miss one made in between.
+func (e *Engine) backfill(ctx context.Context, snapshotName string) error {
+	return internal.BackfillFromPostgres(ctx, e.db, "bleve_backfill", snapshotName, func(docs []internal.Document) error {
+		return e.Batch(ctx, docs)
+	})
+}
@@ -0,0 +1,41 @@
+package bleve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pglogrepl"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/internal"
+)
+
+// maxHealthyLagBytes is the replication lag, in WAL bytes, past which
+// Health reports the engine unhealthy. 16MiB is generous enough to
+// absorb normal write bursts without false-alarming.
+const maxHealthyLagBytes = 16 << 20
+
+// Health reports how far the on-disk index has fallen behind the
+// products table, measured as the gap between the current WAL position
+// and the last one the replication syncer applied. It satisfies
+// internal.HealthReporter.
+func (e *Engine) Health(ctx context.Context) (internal.Health, error) {
+	var currentStr string
+	if err := e.db.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()").Scan(&currentStr); err != nil {
+		return internal.Health{}, err
+	}
+	current, err := pglogrepl.ParseLSN(currentStr)
+	if err != nil {
+		return internal.Health{}, err
+	}
+
+	applied := pglogrepl.LSN(e.lastApplied.Load())
+	lagBytes := int64(current) - int64(applied)
+	if lagBytes < 0 {
+		lagBytes = 0
+	}
+
+	return internal.Health{
+		Healthy: lagBytes < maxHealthyLagBytes,
+		Detail:  fmt.Sprintf("applied=%s current=%s lag_bytes=%d", applied, current, lagBytes),
+	}, nil
+}
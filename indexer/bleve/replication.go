@@ -0,0 +1,230 @@
+package bleve
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/internal"
+)
+
+const (
+	publicationName       = "search_index_products"
+	slotName              = "search_index_bleve"
+	standbyMessageTimeout = 10 * time.Second
+)
+
+// createReplicationSlot creates the logical replication slot fresh (only
+// called on the stale-index path, where no slot can already exist for
+// this index generation) and exports the snapshot Postgres guarantees is
+// consistent with the slot's reported consistent point. Backfilling
+// against that exported snapshot, then streaming from the slot starting
+// at that same consistent point, is what makes the backfill and the
+// streamed WAL gapless and non-overlapping: creating the slot after
+// taking a pg_current_wal_lsn() snapshot (the previous approach) left a
+// window where writes committed between the snapshot and slot creation
+// would be seen by neither.
+func (e *Engine) createReplicationSlot(ctx context.Context) (pglogrepl.LSN, string, error) {
+	conn, err := pgconn.Connect(ctx, e.replConnStr)
+	if err != nil {
+		return 0, "", fmt.Errorf("replication connect: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	result, err := pglogrepl.CreateReplicationSlot(ctx, conn, slotName, "pgoutput",
+		pglogrepl.CreateReplicationSlotOptions{Mode: pglogrepl.LogicalReplication, SnapshotAction: "EXPORT_SNAPSHOT"})
+	if err != nil && isAlreadyExists(err) {
+		// A slot surviving from an interrupted rebuild can't be reused -
+		// it has no exported snapshot left to back a fresh backfill -
+		// so drop it and take a clean one.
+		if dropErr := pglogrepl.DropReplicationSlot(ctx, conn, slotName, pglogrepl.DropReplicationSlotOptions{}); dropErr != nil {
+			return 0, "", fmt.Errorf("drop stale replication slot: %w", dropErr)
+		}
+		result, err = pglogrepl.CreateReplicationSlot(ctx, conn, slotName, "pgoutput",
+			pglogrepl.CreateReplicationSlotOptions{Mode: pglogrepl.LogicalReplication, SnapshotAction: "EXPORT_SNAPSHOT"})
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("create replication slot: %w", err)
+	}
+
+	consistentPoint, err := pglogrepl.ParseLSN(result.ConsistentPoint)
+	if err != nil {
+		return 0, "", fmt.Errorf("parse consistent point: %w", err)
+	}
+	return consistentPoint, result.SnapshotName, nil
+}
+
+// runSync streams changes to the products table from startLSN onward and
+// applies them to the index, checkpointing its progress so a restart can
+// resume instead of re-backfilling. It runs for the lifetime of the
+// process; connection and replication-protocol errors are logged and end
+// the syncer rather than crashing the service, since stale search
+// results are preferable to downtime. A WAL record that fails to parse or
+// apply, or a checkpoint that fails to store, is logged and skipped.
+func (e *Engine) runSync(startLSN pglogrepl.LSN) {
+	ctx := context.Background()
+
+	if err := e.ensurePublication(ctx); err != nil {
+		log.Printf("bleve: ensure publication: %v", err)
+		return
+	}
+
+	conn, err := pgconn.Connect(ctx, e.replConnStr)
+	if err != nil {
+		log.Printf("bleve: replication connect: %v", err)
+		return
+	}
+	defer conn.Close(ctx)
+
+	if _, err := pglogrepl.CreateReplicationSlot(ctx, conn, slotName, "pgoutput",
+		pglogrepl.CreateReplicationSlotOptions{Mode: pglogrepl.LogicalReplication}); err != nil && !isAlreadyExists(err) {
+		log.Printf("bleve: create replication slot: %v", err)
+		return
+	}
+
+	pluginArgs := []string{"proto_version '1'", "publication_names '" + publicationName + "'"}
+	if err := pglogrepl.StartReplication(ctx, conn, slotName, startLSN, pglogrepl.StartReplicationOptions{PluginArgs: pluginArgs}); err != nil {
+		log.Printf("bleve: start replication: %v", err)
+		return
+	}
+
+	relations := map[uint32]*pglogrepl.RelationMessage{}
+	clientXLogPos := startLSN
+	nextStandbyDeadline := time.Now().Add(standbyMessageTimeout)
+
+	for {
+		if time.Now().After(nextStandbyDeadline) {
+			if err := pglogrepl.SendStandbyStatusUpdate(ctx, conn, pglogrepl.StandbyStatusUpdate{WALWritePosition: clientXLogPos}); err != nil {
+				log.Printf("bleve: send standby status: %v", err)
+				return
+			}
+			nextStandbyDeadline = time.Now().Add(standbyMessageTimeout)
+		}
+
+		recvCtx, cancel := context.WithDeadline(ctx, nextStandbyDeadline)
+		rawMsg, err := conn.ReceiveMessage(recvCtx)
+		cancel()
+		if err != nil {
+			if pgconn.Timeout(err) {
+				continue
+			}
+			log.Printf("bleve: receive replication message: %v", err)
+			return
+		}
+
+		copyData, ok := rawMsg.(*pgproto3.CopyData)
+		if !ok || len(copyData.Data) == 0 {
+			continue
+		}
+
+		switch copyData.Data[0] {
+		case pglogrepl.PrimaryKeepaliveMessageByteID:
+			pka, err := pglogrepl.ParsePrimaryKeepaliveMessage(copyData.Data[1:])
+			if err != nil {
+				log.Printf("bleve: parse keepalive: %v", err)
+				continue
+			}
+			if pka.ReplyRequested {
+				nextStandbyDeadline = time.Time{}
+			}
+
+		case pglogrepl.XLogDataByteID:
+			xld, err := pglogrepl.ParseXLogData(copyData.Data[1:])
+			if err != nil {
+				log.Printf("bleve: parse xlog data: %v", err)
+				continue
+			}
+			if err := e.applyWAL(relations, xld.WALData); err != nil {
+				log.Printf("bleve: apply wal record: %v", err)
+				continue
+			}
+			clientXLogPos = xld.WALStart + pglogrepl.LSN(len(xld.WALData))
+			e.lastApplied.Store(uint64(clientXLogPos))
+			if err := e.storeCheckpoint(ctx, clientXLogPos); err != nil {
+				log.Printf("bleve: store checkpoint: %v", err)
+			}
+		}
+	}
+}
+
+// applyWAL decodes a single pgoutput message and, for inserts/updates/
+// deletes on the products table, applies the equivalent Index/Delete
+// call so the on-disk index mirrors Postgres.
+func (e *Engine) applyWAL(relations map[uint32]*pglogrepl.RelationMessage, data []byte) error {
+	msg, err := pglogrepl.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	switch m := msg.(type) {
+	case *pglogrepl.RelationMessage:
+		relations[m.RelationID] = m
+	case *pglogrepl.InsertMessage:
+		return e.applyUpsert(relations, m.RelationID, m.Tuple)
+	case *pglogrepl.UpdateMessage:
+		return e.applyUpsert(relations, m.RelationID, m.NewTuple)
+	case *pglogrepl.DeleteMessage:
+		return e.applyDelete(relations, m.RelationID, m.OldTuple)
+	}
+	return nil
+}
+
+func (e *Engine) applyUpsert(relations map[uint32]*pglogrepl.RelationMessage, relID uint32, tuple *pglogrepl.TupleData) error {
+	doc, ok := decodeDocument(relations, relID, tuple)
+	if !ok {
+		return nil
+	}
+	return e.Index(context.Background(), doc)
+}
+
+func (e *Engine) applyDelete(relations map[uint32]*pglogrepl.RelationMessage, relID uint32, tuple *pglogrepl.TupleData) error {
+	doc, ok := decodeDocument(relations, relID, tuple)
+	if !ok {
+		return nil
+	}
+	return e.Delete(context.Background(), doc.ID)
+}
+
+func decodeDocument(relations map[uint32]*pglogrepl.RelationMessage, relID uint32, tuple *pglogrepl.TupleData) (internal.Document, bool) {
+	rel, ok := relations[relID]
+	if !ok || tuple == nil {
+		return internal.Document{}, false
+	}
+
+	var doc internal.Document
+	for i, col := range rel.Columns {
+		if i >= len(tuple.Columns) || tuple.Columns[i].DataType != 't' {
+			continue
+		}
+		switch col.Name {
+		case "id":
+			doc.ID = string(tuple.Columns[i].Data)
+		case "name":
+			doc.Name = string(tuple.Columns[i].Data)
+		case "category":
+			doc.Category = string(tuple.Columns[i].Data)
+		}
+	}
+	if doc.ID == "" {
+		return internal.Document{}, false
+	}
+	return doc, true
+}
+
+func (e *Engine) ensurePublication(ctx context.Context) error {
+	_, err := e.db.ExecContext(ctx, "CREATE PUBLICATION "+publicationName+" FOR TABLE products")
+	if err != nil && !isAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func isAlreadyExists(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "already exists")
+}
@@ -0,0 +1,40 @@
+package bleve
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pglogrepl"
+)
+
+// stateTable records, per engine, the last WAL LSN its replication
+// syncer has applied, so a restart can resume instead of re-backfilling.
+const stateTable = "search_index_state"
+
+func (e *Engine) ensureStateTable(ctx context.Context) error {
+	_, err := e.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+stateTable+` (
+		engine   TEXT PRIMARY KEY,
+		last_lsn TEXT NOT NULL
+	)`)
+	return err
+}
+
+func (e *Engine) loadCheckpoint(ctx context.Context) (pglogrepl.LSN, error) {
+	var lsnStr string
+	err := e.db.QueryRowContext(ctx, `SELECT last_lsn FROM `+stateTable+` WHERE engine = 'bleve'`).Scan(&lsnStr)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("bleve: index is current but no checkpoint was recorded")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return pglogrepl.ParseLSN(lsnStr)
+}
+
+func (e *Engine) storeCheckpoint(ctx context.Context, lsn pglogrepl.LSN) error {
+	_, err := e.db.ExecContext(ctx, `
+		INSERT INTO `+stateTable+` (engine, last_lsn) VALUES ('bleve', $1)
+		ON CONFLICT (engine) DO UPDATE SET last_lsn = EXCLUDED.last_lsn`, lsn.String())
+	return err
+}
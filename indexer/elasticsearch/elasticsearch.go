@@ -0,0 +1,373 @@
+// Package elasticsearch adapts an Elasticsearch cluster to the
+// indexer.Indexer interface, for deployments whose catalog has outgrown
+// the embedded Bleve engine.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	esapi "github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/internal"
+)
+
+// IndexVersion is bumped whenever the index mapping changes in a way that
+// requires a full rebuild. It is stamped into the meta document at
+// metaDocID so Init can detect a stale index left over from a previous
+// mapping.
+const IndexVersion = 1
+
+const (
+	indexName = "products"
+	metaDocID = "_meta"
+)
+
+type metaDoc struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+// Engine is an Elasticsearch-backed implementation of internal.Indexer.
+type Engine struct {
+	db     *sql.DB
+	client *esapi.Client
+}
+
+// New returns an Elasticsearch engine pointed at the cluster described by
+// addrs (typically from the ELASTICSEARCH_URL env var). db is used to
+// backfill the index from the products table when it's missing or stale.
+func New(db *sql.DB, addrs []string) (*Engine, error) {
+	client, err := esapi.NewClient(esapi.Config{Addresses: addrs})
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch: new client: %w", err)
+	}
+	return &Engine{db: db, client: client}, nil
+}
+
+func (e *Engine) Init(ctx context.Context) error {
+	stale, err := e.isStale(ctx)
+	if err != nil {
+		return fmt.Errorf("elasticsearch: check schema version: %w", err)
+	}
+	if !stale {
+		return nil
+	}
+
+	if err := e.recreateIndex(ctx); err != nil {
+		return fmt.Errorf("elasticsearch: recreate index: %w", err)
+	}
+	if err := e.backfill(ctx); err != nil {
+		return fmt.Errorf("elasticsearch: backfill: %w", err)
+	}
+	return e.putMeta(ctx)
+}
+
+// isStale reports whether the index is missing or was built under an
+// older IndexVersion.
+func (e *Engine) isStale(ctx context.Context) (bool, error) {
+	res, err := e.client.Get(indexName, metaDocID, e.client.Get.WithContext(ctx))
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return true, nil
+	}
+	if res.IsError() {
+		return false, fmt.Errorf("get meta: %s", res.String())
+	}
+
+	var body struct {
+		Source metaDoc `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return false, err
+	}
+	return body.Source.SchemaVersion != IndexVersion, nil
+}
+
+func (e *Engine) recreateIndex(ctx context.Context) error {
+	_, _ = e.client.Indices.Delete([]string{indexName}, e.client.Indices.Delete.WithContext(ctx))
+
+	mapping := `{
+		"mappings": {
+			"properties": {
+				"Name":     {"type": "text", "analyzer": "english"},
+				"Category": {"type": "keyword"}
+			}
+		}
+	}`
+	res, err := e.client.Indices.Create(indexName,
+		e.client.Indices.Create.WithContext(ctx),
+		e.client.Indices.Create.WithBody(bytes.NewReader([]byte(mapping))),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("create index: %s", res.String())
+	}
+	return nil
+}
+
+func (e *Engine) putMeta(ctx context.Context) error {
+	body, err := json.Marshal(metaDoc{SchemaVersion: IndexVersion})
+	if err != nil {
+		return err
+	}
+	res, err := e.client.Index(indexName, bytes.NewReader(body),
+		e.client.Index.WithDocumentID(metaDocID),
+		e.client.Index.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("put meta: %s", res.String())
+	}
+	return nil
+}
+
+// backfill loads every row in the products table into the index via
+// internal.BackfillFromPostgres. Called whenever Init finds the index
+// missing or stale.
+func (e *Engine) backfill(ctx context.Context) error {
+	return internal.BackfillFromPostgres(ctx, e.db, "es_backfill", "", func(docs []internal.Document) error {
+		return e.Batch(ctx, docs)
+	})
+}
+
+func (e *Engine) Ping(ctx context.Context) error {
+	res, err := e.client.Ping(e.client.Ping.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch: ping: %s", res.String())
+	}
+	return nil
+}
+
+func (e *Engine) Close() error {
+	return nil
+}
+
+func (e *Engine) Index(ctx context.Context, doc internal.Document) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	res, err := e.client.Index(indexName, bytes.NewReader(body),
+		e.client.Index.WithDocumentID(doc.ID),
+		e.client.Index.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch: index %s: %s", doc.ID, res.String())
+	}
+	return nil
+}
+
+func (e *Engine) Delete(ctx context.Context, id string) error {
+	res, err := e.client.Delete(indexName, id, e.client.Delete.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("elasticsearch: delete %s: %s", id, res.String())
+	}
+	return nil
+}
+
+func (e *Engine) Batch(ctx context.Context, docs []internal.Document) error {
+	var buf bytes.Buffer
+	for _, doc := range docs {
+		meta, _ := json.Marshal(map[string]interface{}{
+			"index": map[string]string{"_index": indexName, "_id": doc.ID},
+		})
+		source, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		buf.Write(meta)
+		buf.WriteByte('\n')
+		buf.Write(source)
+		buf.WriteByte('\n')
+	}
+
+	res, err := e.client.Bulk(&buf, e.client.Bulk.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch: bulk: %s", res.String())
+	}
+	return nil
+}
+
+const defaultHitsPerPage = 20
+
+func (e *Engine) Search(ctx context.Context, params internal.SearchParams) (*internal.SearchResult, error) {
+	page := params.Page
+	if page < 1 {
+		page = 1
+	}
+	hitsPerPage := params.HitsPerPage
+	if hitsPerPage <= 0 {
+		hitsPerPage = defaultHitsPerPage
+	}
+
+	requestBody := map[string]interface{}{
+		"from":  (page - 1) * hitsPerPage,
+		"size":  hitsPerPage,
+		"query": buildQuery(params),
+		"highlight": map[string]interface{}{
+			"fields": map[string]interface{}{
+				"Name":     map[string]interface{}{},
+				"Category": map[string]interface{}{},
+			},
+		},
+	}
+	for _, field := range params.FacetFields {
+		aggs, _ := requestBody["aggs"].(map[string]interface{})
+		if aggs == nil {
+			aggs = map[string]interface{}{}
+			requestBody["aggs"] = aggs
+		}
+		aggs[field] = map[string]interface{}{"terms": map[string]interface{}{"field": field, "size": 20}}
+	}
+
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := e.client.Search(
+		e.client.Search.WithContext(ctx),
+		e.client.Search.WithIndex(indexName),
+		e.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("elasticsearch: search: %s", res.String())
+	}
+
+	var parsed struct {
+		Hits struct {
+			Total struct {
+				Value uint64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID        string              `json:"_id"`
+				Score     float64             `json:"_score"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations map[string]struct {
+			Buckets []struct {
+				Key      string `json:"key"`
+				DocCount uint64 `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	hits := make([]internal.SearchHit, 0, len(parsed.Hits.Hits))
+	for _, h := range parsed.Hits.Hits {
+		if h.ID == metaDocID {
+			continue
+		}
+		hits = append(hits, internal.SearchHit{ID: h.ID, Score: h.Score, HighlightResult: toHighlightResult(h.Highlight)})
+	}
+
+	var facets map[string][]internal.FacetCount
+	if len(parsed.Aggregations) > 0 {
+		facets = make(map[string][]internal.FacetCount, len(parsed.Aggregations))
+		for field, agg := range parsed.Aggregations {
+			counts := make([]internal.FacetCount, 0, len(agg.Buckets))
+			for _, bucket := range agg.Buckets {
+				counts = append(counts, internal.FacetCount{Value: bucket.Key, Count: bucket.DocCount})
+			}
+			facets[field] = counts
+		}
+	}
+
+	nbHits := parsed.Hits.Total.Value
+	nbPages := int((nbHits + uint64(hitsPerPage) - 1) / uint64(hitsPerPage))
+	return &internal.SearchResult{
+		Hits:        hits,
+		Page:        page,
+		HitsPerPage: hitsPerPage,
+		NbHits:      nbHits,
+		NbPages:     nbPages,
+		Facets:      facets,
+	}, nil
+}
+
+// buildQuery turns free text plus any ?filter= constraints into an ES
+// bool query: a bare match when there are no filters, otherwise the
+// match combined with a term filter per filter value.
+func buildQuery(params internal.SearchParams) map[string]interface{} {
+	match := map[string]interface{}{"match": map[string]interface{}{"Name": params.Query}}
+	if len(params.Filters) == 0 {
+		return match
+	}
+
+	var filters []interface{}
+	for field, values := range params.Filters {
+		for _, value := range values {
+			filters = append(filters, map[string]interface{}{"term": map[string]interface{}{field: value}})
+		}
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":   match,
+			"filter": filters,
+		},
+	}
+}
+
+// toHighlightResult converts ES's field->fragments highlight map into
+// the Algolia-style highlightResult map. ES does not expose per-term
+// match locations the way bleve does, so matchLevel is derived from the
+// first fragment alone: "none" if it is empty, "full" if it holds at
+// least as many <em> tags as words, otherwise "partial".
+func toHighlightResult(highlight map[string][]string) map[string]internal.Highlight {
+	if len(highlight) == 0 {
+		return nil
+	}
+	result := make(map[string]internal.Highlight, len(highlight))
+	for field, fragments := range highlight {
+		value := ""
+		if len(fragments) > 0 {
+			value = fragments[0]
+		}
+		level := internal.MatchNone
+		if value != "" {
+			level = internal.MatchPartial
+			if strings.Count(value, "<em>") >= strings.Count(value, " ")+1 {
+				level = internal.MatchFull
+			}
+		}
+		result[field] = internal.Highlight{Value: value, MatchLevel: level}
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+// Package indexer selects and wires up the search engine backend the
+// service runs against, based on the SEARCH_ENGINE env var. The HTTP
+// layer only ever sees the Indexer interface, never a specific backend.
+package indexer
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/bleve"
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/elasticsearch"
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/internal"
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/meilisearch"
+)
+
+// Indexer is re-exported from internal so callers outside the indexer
+// tree can hold and pass around a driver without importing the internal
+// package directly.
+type Indexer = internal.Indexer
+
+// Document is re-exported from internal for the same reason.
+type Document = internal.Document
+
+// SearchResult, SearchHit, SearchParams, Highlight, MatchLevel, and
+// FacetCount are re-exported from internal for the same reason.
+type SearchResult = internal.SearchResult
+type SearchHit = internal.SearchHit
+type SearchParams = internal.SearchParams
+type Highlight = internal.Highlight
+type MatchLevel = internal.MatchLevel
+type FacetCount = internal.FacetCount
+
+const (
+	MatchNone    = internal.MatchNone
+	MatchPartial = internal.MatchPartial
+	MatchFull    = internal.MatchFull
+)
+
+// Health and HealthReporter are re-exported from internal for the same
+// reason; see internal.HealthReporter for which backends implement it.
+type Health = internal.Health
+type HealthReporter = internal.HealthReporter
+
+// New builds the Indexer named by the SEARCH_ENGINE env var ("bleve",
+// "elasticsearch", or "meilisearch"), defaulting to "bleve" when unset.
+// It does not call Init; the caller is responsible for that once a
+// database connection is ready. Init itself runs any backfill the
+// backend needs.
+func New(db *sql.DB) (Indexer, error) {
+	switch strings.ToLower(os.Getenv("SEARCH_ENGINE")) {
+	case "", "bleve":
+		return bleve.New(db, replicationConnString()), nil
+	case "elasticsearch":
+		addr := os.Getenv("ELASTICSEARCH_URL")
+		if addr == "" {
+			addr = "http://localhost:9200"
+		}
+		return elasticsearch.New(db, []string{addr})
+	case "meilisearch":
+		host := os.Getenv("MEILISEARCH_URL")
+		if host == "" {
+			host = "http://localhost:7700"
+		}
+		return meilisearch.New(db, host, os.Getenv("MEILISEARCH_API_KEY")), nil
+	default:
+		return nil, fmt.Errorf("indexer: unknown SEARCH_ENGINE %q", os.Getenv("SEARCH_ENGINE"))
+	}
+}
+
+// replicationConnString builds the Postgres connection string the bleve
+// driver uses for its logical-replication syncer, from the same DB_*
+// env vars as the main application connection, with replication=database
+// set so the server speaks the replication protocol on it.
+func replicationConnString() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&replication=database",
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_NAME"),
+	)
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// backfillBatchSize bounds how many rows BackfillFromPostgres fetches per
+// round trip, so a large catalog is streamed in bounded batches rather
+// than loaded into memory at once.
+const backfillBatchSize = 1000
+
+// BackfillFromPostgres loads every row of the products table into a
+// backend using a server-side cursor, handing each batch to apply so the
+// caller can route it through its own indexing API (bleve's Batch,
+// elasticsearch's bulk API, meilisearch's AddDocuments, ...). cursorName
+// must be unique per caller, since Postgres cursors are scoped to the
+// transaction that declares them but callers share a *sql.DB pool.
+//
+// When snapshotName is non-empty - the bleve driver's case, where the
+// backfill must see exactly the rows as-of a logical replication slot's
+// consistent point - the transaction is pinned to that exported snapshot
+// before the cursor is declared.
+func BackfillFromPostgres(ctx context.Context, db *sql.DB, cursorName, snapshotName string, apply func([]Document) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if snapshotName != "" {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET TRANSACTION SNAPSHOT '%s'", snapshotName)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DECLARE %s CURSOR FOR SELECT id, name, category FROM products ORDER BY id", cursorName)); err != nil {
+		return err
+	}
+
+	for {
+		n, err := backfillBatch(ctx, tx, cursorName, apply)
+		if err != nil {
+			return err
+		}
+		if n < backfillBatchSize {
+			break
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "CLOSE "+cursorName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func backfillBatch(ctx context.Context, tx *sql.Tx, cursorName string, apply func([]Document) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH FORWARD %d FROM %s", backfillBatchSize, cursorName))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var docs []Document
+	for rows.Next() {
+		var id int
+		var name, category string
+		if err := rows.Scan(&id, &name, &category); err != nil {
+			return 0, err
+		}
+		docs = append(docs, Document{ID: strconv.Itoa(id), Name: name, Category: category})
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(docs) > 0 {
+		if err := apply(docs); err != nil {
+			return 0, err
+		}
+	}
+	return len(docs), nil
+}
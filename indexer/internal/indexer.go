@@ -0,0 +1,131 @@
+// Package internal defines the shared contract that every search engine
+// backend under indexer/ must implement, plus the document and result
+// types that flow across that boundary. It is kept internal so that
+// callers outside the indexer subsystem go through the indexer package's
+// driver-selection facade instead of depending on a specific backend.
+package internal
+
+import "context"
+
+// Document is the denormalized record handed to a backend for indexing.
+// It mirrors the subset of a product's fields that are searchable.
+type Document struct {
+	ID       string
+	Name     string
+	Category string
+}
+
+// MatchLevel describes how much of a highlighted field matched the
+// query: the whole field, some tokens within it, or nothing.
+type MatchLevel string
+
+const (
+	MatchNone    MatchLevel = "none"
+	MatchPartial MatchLevel = "partial"
+	MatchFull    MatchLevel = "full"
+)
+
+// Highlight is the highlighted rendering of a single searchable field on
+// a hit, Algolia-hit-object style.
+type Highlight struct {
+	Value        string     `json:"value"`
+	MatchLevel   MatchLevel `json:"matchLevel"`
+	MatchedWords []string   `json:"matchedWords"`
+}
+
+// SearchHit is a single match returned by a backend, identified by the
+// document ID used at index time, along with a per-field highlight
+// breakdown of why it matched.
+type SearchHit struct {
+	ID              string
+	Score           float64
+	HighlightResult map[string]Highlight
+}
+
+// FacetCount is the number of indexed documents carrying a given value
+// for a faceted field.
+type FacetCount struct {
+	Value string
+	Count uint64
+}
+
+// SearchParams bounds and filters a Search call.
+type SearchParams struct {
+	Query string
+
+	// Page is 1-based; Page<=0 is treated as 1.
+	Page int
+	// HitsPerPage<=0 falls back to a backend-defined default.
+	HitsPerPage int
+
+	// Filters restricts results to documents whose field equals each of
+	// the given values; every field/value pair is ANDed together.
+	Filters map[string][]string
+
+	// FacetFields names the fields to compute facet counts over, e.g.
+	// "Category".
+	FacetFields []string
+}
+
+// SearchResult is the backend-agnostic outcome of a Search call.
+type SearchResult struct {
+	Hits []SearchHit
+
+	Page        int
+	HitsPerPage int
+	NbHits      uint64
+	NbPages     int
+
+	// Facets maps a faceted field name to its value counts.
+	Facets map[string][]FacetCount
+}
+
+// Health describes the operational status of a backend, beyond the bare
+// reachability check Ping performs. Backends that replicate asynchronously
+// from a system of record (e.g. the bleve driver's logical-replication
+// syncer) use it to surface how far their view has fallen behind.
+type Health struct {
+	Healthy bool   `json:"healthy"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// HealthReporter is implemented by backends that can report something
+// richer than Ping, such as replication lag. Callers should type-assert
+// for it rather than requiring it on every Indexer, since not every
+// backend has a meaningful notion of lag.
+type HealthReporter interface {
+	Health(ctx context.Context) (Health, error)
+}
+
+// Indexer is implemented by each search engine driver (bleve,
+// elasticsearch, meilisearch, ...). Callers select a concrete
+// implementation through the indexer package's New function based on the
+// SEARCH_ENGINE env var, so the HTTP layer never depends on a specific
+// backend.
+type Indexer interface {
+	// Init prepares the backend for use: opening or creating the
+	// underlying index, verifying its schema version, and rebuilding it
+	// from the system of record if it is missing or stale.
+	Init(ctx context.Context) error
+
+	// Ping reports whether the backend is reachable and ready to serve
+	// requests.
+	Ping(ctx context.Context) error
+
+	// Close releases any resources held by the backend.
+	Close() error
+
+	// Index upserts a single document.
+	Index(ctx context.Context, doc Document) error
+
+	// Delete removes a document by ID. It is not an error to delete an
+	// ID that does not exist.
+	Delete(ctx context.Context, id string) error
+
+	// Search runs a free-text query and returns a page of hits, ordered
+	// by descending score, along with any requested facet counts.
+	Search(ctx context.Context, params SearchParams) (*SearchResult, error)
+
+	// Batch indexes many documents in a single round trip.
+	Batch(ctx context.Context, docs []Document) error
+}
@@ -0,0 +1,269 @@
+// Package meilisearch adapts a Meilisearch instance to the
+// indexer.Indexer interface, for deployments that favor Meilisearch's
+// simpler operational model over Elasticsearch.
+package meilisearch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	meili "github.com/meilisearch/meilisearch-go"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer/internal"
+)
+
+// IndexVersion is bumped whenever the index settings change in a way
+// that requires a full rebuild. It is stamped into the meta index's
+// single document so Init can detect a stale index left over from a
+// previous schema.
+const IndexVersion = 1
+
+const (
+	indexUID     = "products"
+	metaIndexUID = "products_meta"
+	metaDocID    = "meta"
+)
+
+type metaDoc struct {
+	ID            string `json:"id"`
+	SchemaVersion int    `json:"schema_version"`
+}
+
+// productDoc's JSON tags match the field names bleve and elasticsearch
+// index under ("ID", "Name", "Category": see bleve.toBlevePayload and the
+// Document struct's default json marshaling), since facetedFields and
+// parseFilters in search.go send one capitalized field-name convention
+// to whichever backend is selected.
+type productDoc struct {
+	ID       string `json:"ID"`
+	Name     string `json:"Name"`
+	Category string `json:"Category"`
+}
+
+// Engine is a Meilisearch-backed implementation of internal.Indexer.
+type Engine struct {
+	db     *sql.DB
+	client *meili.Client
+	index  *meili.Index
+}
+
+// New returns a Meilisearch engine pointed at host (typically from the
+// MEILISEARCH_URL env var) authenticated with apiKey. db is used to
+// backfill the index from the products table when it's missing or stale.
+func New(db *sql.DB, host, apiKey string) *Engine {
+	client := meili.NewClient(meili.ClientConfig{Host: host, APIKey: apiKey})
+	return &Engine{db: db, client: client, index: client.Index(indexUID)}
+}
+
+func (e *Engine) Init(ctx context.Context) error {
+	stale, err := e.isStale()
+	if err != nil {
+		return fmt.Errorf("meilisearch: check schema version: %w", err)
+	}
+	if !stale {
+		return nil
+	}
+
+	if _, err := e.client.DeleteIndex(indexUID); err != nil {
+		return fmt.Errorf("meilisearch: delete stale index: %w", err)
+	}
+	if _, err := e.client.CreateIndex(&meili.IndexConfig{Uid: indexUID, PrimaryKey: "ID"}); err != nil {
+		return fmt.Errorf("meilisearch: create index: %w", err)
+	}
+	if _, err := e.index.UpdateFilterableAttributes(&[]string{"Category"}); err != nil {
+		return fmt.Errorf("meilisearch: set filterable attributes: %w", err)
+	}
+	if err := e.backfill(ctx); err != nil {
+		return fmt.Errorf("meilisearch: backfill: %w", err)
+	}
+	return e.putMeta()
+}
+
+// isStale reports whether the meta document is missing (never
+// initialized or wiped out alongside a stale index) or was stamped with
+// an older IndexVersion. Any other error - a transient network or auth
+// failure - is propagated rather than treated as "never initialized",
+// since that would otherwise delete and recreate a perfectly live index.
+func (e *Engine) isStale() (bool, error) {
+	metaIndex := e.client.Index(metaIndexUID)
+	var doc metaDoc
+	err := metaIndex.GetDocument(metaDocID, nil, &doc)
+	if err == nil {
+		return doc.SchemaVersion != IndexVersion, nil
+	}
+	if isNotFound(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// isNotFound reports whether err is a Meilisearch 404, the only case
+// that should be read as "index/document never created".
+func isNotFound(err error) bool {
+	var apiErr *meili.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == 404
+	}
+	return false
+}
+
+// backfill loads every row in the products table into the index via
+// internal.BackfillFromPostgres. Called whenever Init finds the index
+// missing or stale.
+func (e *Engine) backfill(ctx context.Context) error {
+	return internal.BackfillFromPostgres(ctx, e.db, "meili_backfill", "", func(docs []internal.Document) error {
+		return e.Batch(ctx, docs)
+	})
+}
+
+func (e *Engine) putMeta() error {
+	if _, err := e.client.CreateIndex(&meili.IndexConfig{Uid: metaIndexUID, PrimaryKey: "id"}); err != nil {
+		return err
+	}
+	metaIndex := e.client.Index(metaIndexUID)
+	_, err := metaIndex.AddDocuments([]metaDoc{{ID: metaDocID, SchemaVersion: IndexVersion}})
+	return err
+}
+
+func (e *Engine) Ping(ctx context.Context) error {
+	if _, err := e.client.Health(); err != nil {
+		return fmt.Errorf("meilisearch: ping: %w", err)
+	}
+	return nil
+}
+
+func (e *Engine) Close() error {
+	return nil
+}
+
+func (e *Engine) Index(ctx context.Context, doc internal.Document) error {
+	_, err := e.index.AddDocuments([]productDoc{toProductDoc(doc)})
+	return err
+}
+
+func (e *Engine) Delete(ctx context.Context, id string) error {
+	_, err := e.index.DeleteDocument(id)
+	return err
+}
+
+func (e *Engine) Batch(ctx context.Context, docs []internal.Document) error {
+	productDocs := make([]productDoc, 0, len(docs))
+	for _, doc := range docs {
+		productDocs = append(productDocs, toProductDoc(doc))
+	}
+	_, err := e.index.AddDocuments(productDocs)
+	return err
+}
+
+const defaultHitsPerPage = 20
+
+func (e *Engine) Search(ctx context.Context, params internal.SearchParams) (*internal.SearchResult, error) {
+	page := params.Page
+	if page < 1 {
+		page = 1
+	}
+	hitsPerPage := params.HitsPerPage
+	if hitsPerPage <= 0 {
+		hitsPerPage = defaultHitsPerPage
+	}
+
+	req := &meili.SearchRequest{
+		Page:                  int64(page),
+		HitsPerPage:           int64(hitsPerPage),
+		AttributesToHighlight: []string{"Name", "Category"},
+		Facets:                params.FacetFields,
+	}
+	if filter := buildFilter(params.Filters); filter != "" {
+		req.Filter = filter
+	}
+
+	res, err := e.index.Search(params.Query, req)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]internal.SearchHit, 0, len(res.Hits))
+	for _, raw := range res.Hits {
+		doc, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := doc["ID"].(string)
+		hits = append(hits, internal.SearchHit{ID: id, HighlightResult: toHighlightResult(doc)})
+	}
+
+	var facets map[string][]internal.FacetCount
+	if distribution, ok := res.FacetDistribution.(map[string]interface{}); ok && len(distribution) > 0 {
+		facets = make(map[string][]internal.FacetCount, len(distribution))
+		for field, raw := range distribution {
+			values, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			counts := make([]internal.FacetCount, 0, len(values))
+			for value, count := range values {
+				n, _ := count.(float64)
+				counts = append(counts, internal.FacetCount{Value: value, Count: uint64(n)})
+			}
+			facets[field] = counts
+		}
+	}
+
+	return &internal.SearchResult{
+		Hits:        hits,
+		Page:        int(res.Page),
+		HitsPerPage: int(res.HitsPerPage),
+		NbHits:      uint64(res.TotalHits),
+		NbPages:     int(res.TotalPages),
+		Facets:      facets,
+	}, nil
+}
+
+// buildFilter joins the ?filter= constraints into a Meilisearch filter
+// expression, ANDing every field/value pair together.
+func buildFilter(filters map[string][]string) string {
+	if len(filters) == 0 {
+		return ""
+	}
+	var clauses []string
+	for field, values := range filters {
+		for _, value := range values {
+			clauses = append(clauses, fmt.Sprintf("%s = %q", field, value))
+		}
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// toHighlightResult reads Meilisearch's "_formatted" field (populated
+// because AttributesToHighlight was set) into the Algolia-style
+// highlightResult map.
+func toHighlightResult(doc map[string]interface{}) map[string]internal.Highlight {
+	formatted, ok := doc["_formatted"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make(map[string]internal.Highlight, len(formatted))
+	for field, raw := range formatted {
+		if field == "ID" {
+			continue
+		}
+		value, _ := raw.(string)
+		level := internal.MatchNone
+		if strings.Contains(value, "<em>") {
+			level = internal.MatchPartial
+			if strings.Count(value, "<em>") >= strings.Count(value, " ")+1 {
+				level = internal.MatchFull
+			}
+		}
+		result[field] = internal.Highlight{Value: value, MatchLevel: level}
+	}
+	return result
+}
+
+func toProductDoc(doc internal.Document) productDoc {
+	return productDoc{ID: doc.ID, Name: doc.Name, Category: doc.Category}
+}
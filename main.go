@@ -14,10 +14,11 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/blevesearch/bleve/v2"
 	"github.com/go-chi/chi/v5"
 	lru "github.com/hashicorp/golang-lru/v2"
 	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer"
 )
 
 type Product struct {
@@ -28,7 +29,7 @@ type Product struct {
 
 var (
 	db        *sql.DB
-	index     bleve.Index
+	idx       indexer.Indexer
 	cache     *lru.Cache[int, Product]
 	cacheLock sync.RWMutex
 )
@@ -38,12 +39,29 @@ func main() {
 	defer db.Close()
 
 	initCache()
-	createSearchIndex()
+	initIndex()
+	defer idx.Close()
+
+	auth, err := newJWTAuth()
+	if err != nil {
+		log.Fatal("Failed to configure JWT auth:", err)
+	}
 
 	r := chi.NewRouter()
 	r.Get("/search", searchHandler())
-	r.Post("/products", addProductHandler())
-	r.Delete("/products/{id}", deleteProductHandler())
+	r.Get("/healthz", healthzHandler())
+	r.Post("/auth/token", issueTokenHandler(auth))
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.requireScope("write"))
+		r.Post("/products", addProductHandler())
+		r.Post("/products/bulk", addBulkProductHandler())
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.requireScope("admin"))
+		r.Delete("/products/{id}", deleteProductHandler())
+	})
 
 	srv := &http.Server{
 		Addr:    ":8080",
@@ -51,7 +69,14 @@ func main() {
 	}
 
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		certFile, keyFile := os.Getenv("TLS_CERT"), os.Getenv("TLS_KEY")
+		if certFile != "" && keyFile != "" {
+			err = srv.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal(err)
 		}
 	}()
@@ -108,108 +133,16 @@ func initCache() {
 	}
 }
 
-func createSearchIndex() {
-	mapping := bleve.NewIndexMapping()
-	docMapping := bleve.NewDocumentMapping()
-
-	nameField := bleve.NewTextFieldMapping()
-	nameField.Analyzer = "en"
-	docMapping.AddFieldMappingsAt("Name", nameField)
-
-	mapping.AddDocumentMapping("product", docMapping)
-	mapping.DefaultAnalyzer = "en"
-
+func initIndex() {
 	var err error
-	index, err = bleve.NewMemOnly(mapping)
+	idx, err = indexer.New(db)
 	if err != nil {
-		log.Fatal("Failed to create search index:", err)
-	}
-
-	rows, err := db.Query("SELECT id, name FROM products")
-	if err != nil {
-		log.Fatal("Failed to load search index:", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var p Product
-		if err := rows.Scan(&p.ID, &p.Name); err != nil {
-			log.Fatal("Scan error:", err)
-		}
-		index.Index(strconv.Itoa(p.ID), map[string]interface{}{
-			"ID":   p.ID,
-			"Name": p.Name,
-		})
+		log.Fatal("Failed to select search engine:", err)
 	}
-	log.Println("Search index created")
-}
-
-func searchHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		query := r.URL.Query().Get("q")
-		if query == "" {
-			http.Error(w, "Missing search query", http.StatusBadRequest)
-			return
-		}
-
-		searchRequest := bleve.NewSearchRequest(bleve.NewMatchQuery(query))
-		searchRequest.Size = 50
-		searchResult, err := index.Search(searchRequest)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		results := make([]Product, 0, 50)
-		var mu sync.Mutex
-		var wg sync.WaitGroup
-
-		for _, hit := range searchResult.Hits {
-			wg.Add(1)
-			go func(idStr string) {
-				defer wg.Done()
-				id, _ := strconv.Atoi(idStr)
-
-				// Try cache first
-				cacheLock.RLock()
-				if product, ok := cache.Get(id); ok {
-					cacheLock.RUnlock()
-					mu.Lock()
-					results = append(results, product)
-					mu.Unlock()
-					return
-				}
-				cacheLock.RUnlock()
-
-				// Query database if not in cache
-				var p Product
-				err := db.QueryRow(
-					"SELECT id, name, category FROM products WHERE id = $1",
-					id,
-				).Scan(&p.ID, &p.Name, &p.Category)
-				if err != nil {
-					return
-				}
-
-				// Add to cache
-				cacheLock.Lock()
-				cache.Add(p.ID, p)
-				cacheLock.Unlock()
-
-				mu.Lock()
-				results = append(results, p)
-				mu.Unlock()
-			}(hit.ID)
-		}
-
-		wg.Wait()
-		if len(results) > 50 {
-			results = results[:50]
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(results)
+	if err := idx.Init(context.Background()); err != nil {
+		log.Fatal("Failed to initialize search index:", err)
 	}
+	log.Println("Search index ready")
 }
 
 func addProductHandler() http.HandlerFunc {
@@ -230,10 +163,14 @@ func addProductHandler() http.HandlerFunc {
 		}
 
 		// Update search index
-		index.Index(strconv.Itoa(p.ID), map[string]interface{}{
-			"ID":   p.ID,
-			"Name": p.Name,
-		})
+		if err := idx.Index(r.Context(), indexer.Document{
+			ID:       strconv.Itoa(p.ID),
+			Name:     p.Name,
+			Category: p.Category,
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		// Add to cache
 		cacheLock.Lock()
@@ -262,7 +199,10 @@ func deleteProductHandler() http.HandlerFunc {
 		}
 
 		// Remove from search index
-		index.Delete(strconv.Itoa(id))
+		if err := idx.Delete(r.Context(), strconv.Itoa(id)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		// Remove from cache
 		cacheLock.Lock()
@@ -273,6 +213,37 @@ func deleteProductHandler() http.HandlerFunc {
 	}
 }
 
+// healthzHandler reports whether the search index is reachable and, for
+// backends that replicate asynchronously from Postgres (see
+// indexer.HealthReporter), how far behind it has fallen.
+func healthzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := idx.Ping(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		reporter, ok := idx.(indexer.HealthReporter)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(indexer.Health{Healthy: true})
+			return
+		}
+
+		health, err := reporter.Health(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !health.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(health)
+	}
+}
+
 func handleShutdown(srv *http.Server) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/MrigankaDebnath03/GoFileSearchDocker/indexer"
+)
+
+// defaultRequestTimeout bounds how long a search may run end to end when
+// REQUEST_TIMEOUT is unset.
+const defaultRequestTimeout = 5 * time.Second
+
+// defaultSearchConcurrency bounds how many cache lookups a single search
+// request may have in flight when SEARCH_CONCURRENCY is unset.
+const defaultSearchConcurrency = 8
+
+// searchHit is a single Algolia-style hit: the product plus why it
+// matched.
+type searchHit struct {
+	Product
+	HighlightResult map[string]indexer.Highlight `json:"highlightResult,omitempty"`
+}
+
+// facetCount is a single value's count within a faceted field.
+type facetCount struct {
+	Value string `json:"value"`
+	Count uint64 `json:"count"`
+}
+
+// pagination mirrors the page/hitsPerPage/nbHits/nbPages shape of the
+// backend's SearchResult.
+type pagination struct {
+	Page        int    `json:"page"`
+	HitsPerPage int    `json:"hitsPerPage"`
+	NbHits      uint64 `json:"nbHits"`
+	NbPages     int    `json:"nbPages"`
+}
+
+type searchResponse struct {
+	Hits       []searchHit             `json:"hits"`
+	Facets     map[string][]facetCount `json:"facets,omitempty"`
+	Pagination pagination              `json:"pagination"`
+}
+
+// facetedFields are grouped into top-level facet counts on every search.
+var facetedFields = []string{"Category"}
+
+func requestTimeout() time.Duration {
+	if raw := os.Getenv("REQUEST_TIMEOUT"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			return d
+		}
+	}
+	return defaultRequestTimeout
+}
+
+func searchConcurrency() int {
+	if raw := os.Getenv("SEARCH_CONCURRENCY"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultSearchConcurrency
+}
+
+func searchHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			http.Error(w, "Missing search query", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout())
+		defer cancel()
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		hitsPerPage, _ := strconv.Atoi(r.URL.Query().Get("hitsPerPage"))
+
+		searchResult, err := idx.Search(ctx, indexer.SearchParams{
+			Query:       query,
+			Page:        page,
+			HitsPerPage: hitsPerPage,
+			Filters:     parseFilters(r.URL.Query()["filter"]),
+			FacetFields: facetedFields,
+		})
+		if err != nil {
+			writeSearchError(w, ctx, err)
+			return
+		}
+
+		hits, err := hydrateHits(ctx, searchResult.Hits)
+		if err != nil {
+			writeSearchError(w, ctx, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(searchResponse{
+			Hits:   hits,
+			Facets: toFacetCounts(searchResult.Facets),
+			Pagination: pagination{
+				Page:        searchResult.Page,
+				HitsPerPage: searchResult.HitsPerPage,
+				NbHits:      searchResult.NbHits,
+				NbPages:     searchResult.NbPages,
+			},
+		})
+	}
+}
+
+// writeSearchError reports a client disconnect or deadline as a short
+// abort rather than a generic 500, and logs rather than writing a body
+// once the client is known to be gone.
+func writeSearchError(w http.ResponseWriter, ctx context.Context, err error) {
+	if ctx.Err() == context.Canceled {
+		log.Println("search: client disconnected, aborting:", err)
+		w.WriteHeader(499)
+		return
+	}
+	status := http.StatusInternalServerError
+	if ctx.Err() == context.DeadlineExceeded {
+		status = http.StatusGatewayTimeout
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// hitLookup carries a search hit through hydration alongside whatever
+// product record was resolved for it, preserving the bleve score order
+// the caller assembles the final hits in.
+type hitLookup struct {
+	hit     indexer.SearchHit
+	product Product
+	found   bool
+}
+
+// hydrateHits resolves each hit's Product, preferring the cache and
+// falling back to a single batched `WHERE id = ANY($1)` query for
+// whatever misses the cache, instead of one query per hit. Cache checks
+// run concurrently, bounded by SEARCH_CONCURRENCY, and the whole pass is
+// cancelled as soon as ctx is (client disconnect or REQUEST_TIMEOUT).
+func hydrateHits(ctx context.Context, hits []indexer.SearchHit) ([]searchHit, error) {
+	lookups := make([]hitLookup, len(hits))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(searchConcurrency())
+
+	for i, hit := range hits {
+		i, hit := i, hit
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			id, err := strconv.Atoi(hit.ID)
+			if err != nil {
+				lookups[i] = hitLookup{hit: hit}
+				return nil
+			}
+
+			cacheLock.RLock()
+			product, ok := cache.Get(id)
+			cacheLock.RUnlock()
+
+			lookups[i] = hitLookup{hit: hit, product: product, found: ok}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if err := fetchMissing(ctx, lookups); err != nil {
+		return nil, err
+	}
+
+	results := make([]searchHit, 0, len(lookups))
+	for _, l := range lookups {
+		if !l.found {
+			continue
+		}
+		results = append(results, searchHit{Product: l.product, HighlightResult: l.hit.HighlightResult})
+	}
+	return results, nil
+}
+
+// fetchMissing resolves every cache-miss in lookups with one
+// `SELECT ... WHERE id = ANY($1)` query rather than a query per miss,
+// and populates the cache with what it finds.
+func fetchMissing(ctx context.Context, lookups []hitLookup) error {
+	missingIDs := make([]int, 0)
+	indexByID := make(map[int]int, len(lookups))
+	for i, l := range lookups {
+		if l.found {
+			continue
+		}
+		id, err := strconv.Atoi(l.hit.ID)
+		if err != nil {
+			continue
+		}
+		missingIDs = append(missingIDs, id)
+		indexByID[id] = i
+	}
+	if len(missingIDs) == 0 {
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT id, name, category FROM products WHERE id = ANY($1)", missingIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Category); err != nil {
+			return err
+		}
+
+		cacheLock.Lock()
+		cache.Add(p.ID, p)
+		cacheLock.Unlock()
+
+		if i, ok := indexByID[p.ID]; ok {
+			lookups[i].product = p
+			lookups[i].found = true
+		}
+	}
+	return rows.Err()
+}
+
+// parseFilters turns repeated ?filter=field:value query params into the
+// field->values map indexer.SearchParams expects, capitalizing the field
+// name to match the Document struct fields (e.g. "category" -> "Category").
+func parseFilters(raw []string) map[string][]string {
+	if len(raw) == 0 {
+		return nil
+	}
+	filters := make(map[string][]string, len(raw))
+	for _, f := range raw {
+		field, value, ok := strings.Cut(f, ":")
+		if !ok || field == "" {
+			continue
+		}
+		field = strings.ToUpper(field[:1]) + field[1:]
+		filters[field] = append(filters[field], value)
+	}
+	return filters
+}
+
+func toFacetCounts(facets map[string][]indexer.FacetCount) map[string][]facetCount {
+	if len(facets) == 0 {
+		return nil
+	}
+	out := make(map[string][]facetCount, len(facets))
+	for field, counts := range facets {
+		converted := make([]facetCount, 0, len(counts))
+		for _, c := range counts {
+			converted = append(converted, facetCount{Value: c.Value, Count: c.Count})
+		}
+		out[field] = converted
+	}
+	return out
+}